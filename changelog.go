@@ -13,7 +13,7 @@
 // You should have received a copy of the GNU General Public License
 // along tihe this program. If not, see <https://www.gnu.org/licenses/>.
 
-// Package changelog provides a way to create, parse and convert changelogs. Currently, only parsing Markdown keep-a-changelog style and Debian changelogs is implemented for input, and only Debian changelog for output.
+// Package changelog provides a way to create, parse and convert changelogs. Parsing Markdown keep-a-changelog style, Debian and RPM changelogs is implemented for input, and Markdown, Debian and RPM changelogs for output.
 package changelog
 
 import (
@@ -33,12 +33,136 @@ var (
 
 // Version is a recognized version (following semver conventions)
 type Version struct {
+	Epoch      int // Debian epoch, 0 if none
 	Major      int
 	Minor      int
 	Patch      int
 	Prerelease string
 }
 
+// Compare returns -1, 0 or 1 depending on whether v is less than, equal to or
+// greater than other. Comparison follows SemVer precedence (numeric
+// major/minor/patch, then pre-release ordering), extended with Debian's epoch
+// and tilde semantics: a higher epoch always wins, and a pre-release segment
+// starting with "~" sorts before the empty string, so "1.0~rc1" < "1.0" <
+// "1.0.1".
+func (v Version) Compare(other Version) int {
+	if c := compareInt(v.Epoch, other.Epoch); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, other.Prerelease)
+}
+
+// SatisfiedBy reports whether v satisfies the Debian relational operator op
+// ("<<", "<=", "=", ">=", ">>") against other, e.g.
+// v.SatisfiedBy(">=", Version{Major: 1, Minor: 3})  asks "is v >= 1.3".
+func (v Version) SatisfiedBy(op string, other Version) bool {
+	c := v.Compare(other)
+	switch op {
+	case "<<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case "=":
+		return c == 0
+	case ">=":
+		return c >= 0
+	case ">>":
+		return c > 0
+	default:
+		return false
+	}
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePrerelease compares two pre-release strings. A wholly absent
+// pre-release (the empty string) always sorts after a present one, per
+// SemVer's "1.0.0-rc" < "1.0.0" rule - this also covers Debian's tilde
+// convention, since a tilde-marked pre-release such as "~rc1" is still a
+// non-empty string here. When both sides are present, they compare
+// dot-separated identifier by identifier as SemVer does, with the extra
+// rule that an identifier starting with "~" sorts before the identifier in
+// the other string being absent (i.e. before the end of that string), so
+// pre-release "1.~beta" < "1".
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		switch {
+		case i >= len(as):
+			return -compareToEnd(bs[i])
+		case i >= len(bs):
+			return compareToEnd(as[i])
+		default:
+			if c := compareIdentifier(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// compareToEnd reports how a single pre-release identifier compares to the
+// end of the other (non-empty) pre-release's identifier list: a
+// tilde-prefixed identifier sorts before it (-1), while any other
+// identifier sorts after it (+1), matching SemVer's rule that a longer list
+// of identifiers sharing a common prefix has higher precedence, e.g.
+// "1.0.0-alpha" < "1.0.0-alpha.1".
+func compareToEnd(id string) int {
+	if strings.HasPrefix(id, "~") {
+		return -1
+	}
+	return 1
+}
+
+func compareIdentifier(a, b string) int {
+	if strings.HasPrefix(a, "~") != strings.HasPrefix(b, "~") {
+		if strings.HasPrefix(a, "~") {
+			return -1
+		}
+		return 1
+	}
+	an, aerr := strconv.Atoi(a)
+	bn, berr := strconv.Atoi(b)
+	switch {
+	case aerr == nil && berr == nil:
+		return compareInt(an, bn)
+	case aerr == nil:
+		return -1 // numeric identifiers have lower precedence than alphanumeric ones
+	case berr == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
 // ToVersion converts string to Version (if possible)
 func ToVersion(s string) (v Version, err error) {
 	re := regexp.MustCompile(fmt.Sprintf("^%s$", semver.String()))
@@ -73,6 +197,7 @@ type Release struct {
 	Urgency      string     // urgency in Debian terms, "medium" is used if none is provided
 	Distribution string     // distribution released to (Debian-specific), "stable" is used if none is provided
 	Maintainer   Maintainer // package maintainer
+	RPMRelease   string     // RPM "release" component (the part after the last "-" in an RPM version-release), "1" is used if none is provided
 }
 
 // Maintainer is the maintainer of the package
@@ -81,45 +206,73 @@ type Maintainer struct {
 	Email string
 }
 
-type Changelog map[Version]Release
+// Changelog holds all the releases of a changelog, keyed by Version, plus
+// the name of the VersionFormat its versions are expressed in. Debian,
+// RPM and Markdown consult Format to pick the VersionFormat that orders
+// their releases, falling back to their own native format (dpkg, rpm and
+// semver respectively) if Format names one that isn't registered. Version
+// holds a fixed major.minor.patch, so ParseDebian skips an entry whose
+// version, less any epoch and tilde suffix, is not strict semver (such as
+// "1.0-1ubuntu3"), and ParseRPM reads a two-part version such as "1.0" as
+// "1.0.0", which RPM then emits in that three-part form.
+type Changelog struct {
+	Releases   map[Version]Release
+	Unreleased *Release // unreleased changes, if the source had an "Unreleased" section; nil otherwise
+	Format     string   // registered VersionFormat name, e.g. "semver" or "dpkg"; each consumer falls back to its own native format if empty or unregistered
+}
 
 var (
-	semver = regexp.MustCompile(`(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<prerelease>(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?`)
-	dateRe = regexp.MustCompile(` \d{4}-\d{2}-\d{2}$`)
+	semver   = regexp.MustCompile(`(?P<major>0|[1-9]\d*)\.(?P<minor>0|[1-9]\d*)\.(?P<patch>0|[1-9]\d*)(?:-(?P<prerelease>(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+(?P<buildmetadata>[0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?`)
+	dateRe   = regexp.MustCompile(` \d{4}-\d{2}-\d{2}$`)
+	epochRe  = regexp.MustCompile(`\((\d+):`)
+	debVerRe = regexp.MustCompile(`\(([^)]*)\)`)
 )
 
 // ParseMd reads a markdown file (keep-a-changelog style format) into Changelog
 func ParseMd(r io.Reader) (cl Changelog, err error) {
-	cl = make(map[Version]Release)
+	cl = Changelog{Releases: make(map[Version]Release), Format: "semver"}
 	scanner := bufio.NewScanner(r)
 
 	var (
-		curVer *Version
-		curGrp string
+		curVer       *Version
+		inUnreleased bool
+		curGrp       string
 	)
 
 	for scanner.Scan() {
 		line := scanner.Text()
 
 		switch {
+		case strings.HasPrefix(line, "## [Unreleased]"):
+			if cl.Unreleased == nil {
+				cl.Unreleased = &Release{}
+			}
+			curVer = nil
+			inUnreleased = true
 		case strings.HasPrefix(line, "## "):
 			verString := semver.FindString(line)
 			var v Version
 			v, err = ToVersion(verString)
 			if err == nil {
 				d, _ := time.Parse(" 2006-01-02", dateRe.FindString(line))
-				if _, ok := cl[v]; ok {
+				if _, ok := cl.Releases[v]; ok {
 					err = fmt.Errorf("multiple releases for %s", verString)
 				}
-				cl[v] = Release{Date: d}
+				cl.Releases[v] = Release{Date: d}
 				curVer = &v
+				inUnreleased = false
 			}
 		case strings.HasPrefix(line, "### "):
 			curGrp = strings.TrimPrefix(line, "### ")
-		case strings.HasPrefix(line, "- ") && curVer != nil:
-			rel := cl[*curVer]
-			rel.Changes = append(rel.Changes, Change{Type: curGrp, Body: strings.TrimPrefix(line, "- ")})
-			cl[*curVer] = rel
+		case strings.HasPrefix(line, "- ") && (curVer != nil || inUnreleased):
+			chg := Change{Type: curGrp, Body: strings.TrimPrefix(line, "- ")}
+			if inUnreleased {
+				cl.Unreleased.Changes = append(cl.Unreleased.Changes, chg)
+				break
+			}
+			rel := cl.Releases[*curVer]
+			rel.Changes = append(rel.Changes, chg)
+			cl.Releases[*curVer] = rel
 		}
 	}
 
@@ -128,18 +281,33 @@ func ParseMd(r io.Reader) (cl Changelog, err error) {
 
 // ParseDebian reads a Debian changelog into Changelog
 func ParseDebian(r io.Reader) (cl Changelog, err error) {
-	cl = make(map[Version]Release)
+	cl = Changelog{Releases: make(map[Version]Release), Format: "dpkg"}
 	scanner := bufio.NewScanner(r)
 
 	for scanner.Scan() {
 		line := scanner.Text()
-		verString := semver.FindString(line)
+		verString := ""
+		if m := debVerRe.FindStringSubmatch(line); m != nil {
+			verString = m[1]
+		}
+		base := verString
+		if m := epochRe.FindStringSubmatch(line); m != nil {
+			base = strings.TrimPrefix(base, m[1]+":")
+		}
+		prerelease := ""
+		if i := strings.Index(base, "~"); i >= 0 {
+			base, prerelease = base[:i], base[i:]
+		}
 		var v Version
-		v, err = ToVersion(verString)
+		v, err = ToVersion(base)
 		if err == nil {
+			v.Prerelease = prerelease
+			if m := epochRe.FindStringSubmatch(line); m != nil {
+				v.Epoch, _ = strconv.Atoi(m[1])
+			}
 			var rel Release
-			if _, ok := cl[v]; ok {
-				err = fmt.Errorf("multiple releases for %s", verString)
+			if _, ok := cl.Releases[v]; ok {
+				return cl, fmt.Errorf("multiple releases for %s", verString)
 			}
 			comps := strings.Split(line, " ")
 			for _, comp := range comps {
@@ -187,7 +355,7 @@ func ParseDebian(r io.Reader) (cl Changelog, err error) {
 						break
 					}
 					rel.Date = d
-					cl[v] = rel
+					cl.Releases[v] = rel
 					break
 				}
 			}
@@ -198,37 +366,26 @@ func ParseDebian(r io.Reader) (cl Changelog, err error) {
 
 // Debian outputs Changelog with debian changelog formatting
 func (cl Changelog) Debian(packageName string) (out []byte, err error) {
-	type release struct {
-		v Version
-		d time.Time
+	format, ok := VersionFormatFor(cl.Format)
+	if !ok {
+		format = dpkgFormat{}
 	}
-	releases := make([]release, 0, len(cl))
 
-	for ver, r := range cl {
-		releases = append(releases, release{v: ver, d: r.Date})
+	versions := make([]Version, 0, len(cl.Releases))
+
+	for ver := range cl.Releases {
+		versions = append(versions, ver)
 	}
 
-	sort.SliceStable(releases, func(i, j int) bool {
-		if !releases[i].d.Equal(releases[j].d) {
-			return releases[i].d.Before(releases[j].d)
-		}
-		if releases[i].v.Major != releases[j].v.Major {
-			return releases[i].v.Major < releases[j].v.Major
-		}
-		if releases[i].v.Minor != releases[j].v.Minor {
-			return releases[i].v.Minor < releases[j].v.Minor
-		}
-		if releases[i].v.Patch != releases[j].v.Patch {
-			return releases[i].v.Patch < releases[j].v.Patch
-		}
-		return releases[i].v.Prerelease < releases[j].v.Prerelease
+	sort.SliceStable(versions, func(i, j int) bool {
+		return format.Compare(versions[i], versions[j]) < 0
 	})
 
 	var s string
 
-	for i := range releases {
-		r := releases[len(releases)-i-1]
-		rel := cl[r.v]
+	for i := range versions {
+		v := versions[len(versions)-i-1]
+		rel := cl.Releases[v]
 
 		if rel.Urgency == "" {
 			rel.Urgency = "medium"
@@ -237,9 +394,18 @@ func (cl Changelog) Debian(packageName string) (out []byte, err error) {
 			rel.Distribution = "stable"
 		}
 
-		ver := fmt.Sprintf("%d.%d.%d", r.v.Major, r.v.Minor, r.v.Patch)
-		if r.v.Prerelease != "" {
-			ver = fmt.Sprintf("%s-%s", ver, r.v.Prerelease)
+		ver := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+		if v.Prerelease != "" {
+			// A tilde pre-release (as ParseDebian stores it) already carries
+			// its leading "~"; any other pre-release is joined with "-".
+			if strings.HasPrefix(v.Prerelease, "~") {
+				ver = ver + v.Prerelease
+			} else {
+				ver = fmt.Sprintf("%s-%s", ver, v.Prerelease)
+			}
+		}
+		if v.Epoch != 0 {
+			ver = fmt.Sprintf("%d:%s", v.Epoch, ver)
 		}
 
 		s = s + fmt.Sprintf("%s (%s) %s; urgency=%s\n\n", packageName, ver, rel.Distribution, rel.Urgency)
@@ -252,7 +418,201 @@ func (cl Changelog) Debian(packageName string) (out []byte, err error) {
 			s = s + fmt.Sprintf("  * %s: %s\n", ch.Type, ch.Body)
 		}
 
-		s = s + fmt.Sprintf("\n -- %s <%s>  %s\n\n", rel.Maintainer.Name, rel.Maintainer.Email, r.d.Format(time.RFC1123Z))
+		s = s + fmt.Sprintf("\n -- %s <%s>  %s\n\n", rel.Maintainer.Name, rel.Maintainer.Email, rel.Date.Format(time.RFC1123Z))
+	}
+
+	s = strings.TrimSuffix(s, "\n")
+	out = []byte(s)
+
+	return
+}
+
+// ParseRPM reads an RPM spec file's %changelog block into Changelog. Entries
+// look like:
+//
+//	* Fri Jul 12 2019 John Doe <john@doe.me> - 1.3.0-1
+//	- Added: foo
+//	- Fixed: bar
+func ParseRPM(r io.Reader) (cl Changelog, err error) {
+	cl = Changelog{Releases: make(map[Version]Release), Format: "rpm"}
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "* ") {
+			continue
+		}
+
+		header := strings.TrimPrefix(line, "* ")
+		parts := strings.SplitN(header, " - ", 2)
+		if len(parts) != 2 {
+			err = fmt.Errorf("could not parse entry header: %s", line)
+			break
+		}
+
+		tokens := strings.SplitN(parts[0], " ", 5)
+		if len(tokens) != 5 {
+			err = fmt.Errorf("could not parse entry header: %s", line)
+			break
+		}
+
+		d, e := time.Parse("Mon Jan 2 2006", strings.Join(tokens[:4], " "))
+		if e != nil {
+			err = fmt.Errorf("could not parse release date for %s", line)
+			break
+		}
+
+		var maint Maintainer
+		a := strings.Split(tokens[4], " <")
+		if len(a) == 2 {
+			maint.Name = a[0]
+			maint.Email = strings.TrimSuffix(a[1], ">")
+		} else {
+			err = fmt.Errorf("error parsing maintainer for %s - no email?", line)
+			maint.Name = tokens[4]
+		}
+
+		verRelease := parts[1]
+		verString := verRelease
+		rpmRelease := "1"
+		if i := strings.LastIndex(verRelease, "-"); i >= 0 {
+			verString = verRelease[:i]
+			rpmRelease = verRelease[i+1:]
+		}
+
+		var v Version
+		v, err = ParseDpkgVersion(verString)
+		if err != nil {
+			break
+		}
+		if _, ok := cl.Releases[v]; ok {
+			err = fmt.Errorf("multiple releases for %s", verString)
+			break
+		}
+
+		rel := Release{Date: d, Maintainer: maint, RPMRelease: rpmRelease}
+
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				break
+			}
+			if !strings.HasPrefix(line, "- ") {
+				continue
+			}
+			line = strings.TrimPrefix(line, "- ")
+			var chg Change
+			s := strings.SplitN(line, ": ", 2)
+			if len(s) == 2 {
+				chg.Type = s[0]
+				chg.Body = s[1]
+			} else {
+				chg.Body = line
+			}
+			rel.Changes = append(rel.Changes, chg)
+		}
+
+		cl.Releases[v] = rel
+	}
+
+	return
+}
+
+// RPM outputs Changelog as an RPM spec file %changelog block, newest entry
+// first, grouping each release's changes by Change.Type since RPM bullets
+// carry no keep-a-changelog "Type" prefix of their own.
+func (cl Changelog) RPM(packageName string) (out []byte, err error) {
+	format, ok := VersionFormatFor(cl.Format)
+	if !ok {
+		format = rpmFormat{}
+	}
+
+	versions := make([]Version, 0, len(cl.Releases))
+
+	for ver := range cl.Releases {
+		versions = append(versions, ver)
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return format.Compare(versions[i], versions[j]) < 0
+	})
+
+	var s string
+
+	for i := range versions {
+		v := versions[len(versions)-i-1]
+		rel := cl.Releases[v]
+
+		rpmRelease := rel.RPMRelease
+		if rpmRelease == "" {
+			rpmRelease = "1"
+		}
+
+		ver := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+		if v.Prerelease != "" {
+			ver = fmt.Sprintf("%s-%s", ver, v.Prerelease)
+		}
+		if v.Epoch != 0 {
+			ver = fmt.Sprintf("%d:%s", v.Epoch, ver)
+		}
+
+		s = s + fmt.Sprintf("* %s %s <%s> - %s-%s\n", rel.Date.Format("Mon Jan 2 2006"), rel.Maintainer.Name, rel.Maintainer.Email, ver, rpmRelease)
+
+		sort.SliceStable(rel.Changes, func(i, j int) bool {
+			return rel.Changes[i].Type < rel.Changes[j].Type
+		})
+
+		for _, ch := range rel.Changes {
+			s = s + fmt.Sprintf("- %s: %s\n", ch.Type, ch.Body)
+		}
+
+		s = s + "\n"
+	}
+
+	s = strings.TrimSuffix(s, "\n")
+	out = []byte(s)
+
+	return
+}
+
+// changeTypeOrder is the canonical keep-a-changelog grouping order.
+var changeTypeOrder = []string{"Added", "Changed", "Deprecated", "Removed", "Fixed", "Security"}
+
+// Markdown outputs Changelog as a keep-a-changelog style Markdown document,
+// newest release first, with an [Unreleased] section at the top if one was
+// parsed or set.
+func (cl Changelog) Markdown() (out []byte, err error) {
+	format, ok := VersionFormatFor(cl.Format)
+	if !ok {
+		format = semverFormat{}
+	}
+
+	var s string
+
+	if cl.Unreleased != nil {
+		s = s + "## [Unreleased]\n\n" + renderChanges(cl.Unreleased.Changes)
+	}
+
+	versions := make([]Version, 0, len(cl.Releases))
+	for ver := range cl.Releases {
+		versions = append(versions, ver)
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return format.Compare(versions[i], versions[j]) < 0
+	})
+
+	for i := range versions {
+		v := versions[len(versions)-i-1]
+		rel := cl.Releases[v]
+
+		ver := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+		if v.Prerelease != "" {
+			ver = fmt.Sprintf("%s-%s", ver, v.Prerelease)
+		}
+
+		s = s + fmt.Sprintf("## [%s] - %s\n\n", ver, rel.Date.Format("2006-01-02"))
+		s = s + renderChanges(rel.Changes)
 	}
 
 	s = strings.TrimSuffix(s, "\n")
@@ -260,3 +620,410 @@ func (cl Changelog) Debian(packageName string) (out []byte, err error) {
 
 	return
 }
+
+// renderChanges groups changes by Change.Type in keep-a-changelog's canonical
+// order (falling back to alphabetical order for any other type), rendering
+// each group as a "### Type" heading followed by "- " bullets.
+func renderChanges(changes []Change) string {
+	grouped := make(map[string][]Change)
+	for _, ch := range changes {
+		grouped[ch.Type] = append(grouped[ch.Type], ch)
+	}
+
+	seen := make(map[string]bool, len(changeTypeOrder))
+	var s string
+
+	for _, t := range changeTypeOrder {
+		seen[t] = true
+		if chs, ok := grouped[t]; ok {
+			s = s + renderChangeGroup(t, chs)
+		}
+	}
+
+	var rest []string
+	for t := range grouped {
+		if !seen[t] {
+			rest = append(rest, t)
+		}
+	}
+	sort.Strings(rest)
+	for _, t := range rest {
+		s = s + renderChangeGroup(t, grouped[t])
+	}
+
+	return s
+}
+
+func renderChangeGroup(t string, chs []Change) string {
+	var s string
+	if t != "" {
+		s = s + fmt.Sprintf("### %s\n\n", t)
+	}
+	for _, ch := range chs {
+		s = s + fmt.Sprintf("- %s\n", ch.Body)
+	}
+	return s + "\n"
+}
+
+// BumpLevel identifies which part of a Version a release bump increments.
+type BumpLevel int
+
+const (
+	Patch BumpLevel = iota
+	Minor
+	Major
+	PreRelease
+)
+
+type nextConfig struct {
+	preRelease  string
+	build       string
+	prefix      string
+	stripPrefix bool
+	forceBump   *BumpLevel
+}
+
+// NextOption configures Changelog.Next and Version.Render.
+type NextOption func(*nextConfig)
+
+// WithPreRelease sets the pre-release identifier of the computed version.
+func WithPreRelease(pre string) NextOption {
+	return func(c *nextConfig) { c.preRelease = pre }
+}
+
+// WithBuild attaches build metadata (e.g. "df8891") when rendering a version string.
+func WithBuild(build string) NextOption {
+	return func(c *nextConfig) { c.build = build }
+}
+
+// WithPrefix sets the prefix (e.g. "v") used when rendering a version string.
+func WithPrefix(prefix string) NextOption {
+	return func(c *nextConfig) { c.prefix = prefix; c.stripPrefix = false }
+}
+
+// StripPrefix renders a version string with no prefix.
+func StripPrefix() NextOption {
+	return func(c *nextConfig) { c.stripPrefix = true }
+}
+
+// ForceBump overrides the bump level Next would otherwise infer from the
+// Unreleased changes.
+func ForceBump(level BumpLevel) NextOption {
+	return func(c *nextConfig) { c.forceBump = &level }
+}
+
+// Next computes the version that follows current, inspecting the changes
+// recorded in the Unreleased section (see ParseMd) according to
+// keep-a-changelog conventions: any Removed change, or a Changed entry whose
+// body starts with "BREAKING: ", bumps Major; any Added change bumps Minor;
+// only Fixed or Security changes bump Patch. ForceBump overrides the
+// inferred level, and WithPreRelease sets the pre-release identifier of the
+// result.
+func (cl Changelog) Next(current Version, opts ...NextOption) (Version, error) {
+	cfg := &nextConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var unreleased []Change
+	if cl.Unreleased != nil {
+		unreleased = cl.Unreleased.Changes
+	}
+
+	level := inferBump(unreleased)
+	if cfg.forceBump != nil {
+		level = *cfg.forceBump
+	}
+
+	next := current
+	switch level {
+	case Major:
+		next.Major++
+		next.Minor = 0
+		next.Patch = 0
+	case Minor:
+		next.Minor++
+		next.Patch = 0
+	case Patch:
+		next.Patch++
+	case PreRelease:
+		// numeric components stay put; only the pre-release identifier changes
+	}
+	next.Prerelease = cfg.preRelease
+
+	return next, nil
+}
+
+func inferBump(changes []Change) BumpLevel {
+	level := Patch
+	for _, ch := range changes {
+		switch {
+		case ch.Type == "Removed", ch.Type == "Changed" && strings.HasPrefix(ch.Body, "BREAKING: "):
+			return Major
+		case ch.Type == "Added":
+			if level < Minor {
+				level = Minor
+			}
+		}
+	}
+	return level
+}
+
+// Bump moves all changes recorded in the Unreleased section into a new
+// release v dated today, clearing the Unreleased section. It takes a
+// pointer receiver, unlike Changelog's other methods, because clearing
+// Unreleased means setting the field to nil rather than mutating Releases.
+func (cl *Changelog) Bump(v Version) {
+	var rel Release
+	if cl.Unreleased != nil {
+		rel = *cl.Unreleased
+	}
+	rel.Date = time.Now()
+	cl.Releases[v] = rel
+	cl.Unreleased = nil
+}
+
+// Render formats v as a version string. WithPrefix prepends a prefix (e.g.
+// "v"), StripPrefix suppresses it, and WithBuild appends "+build" metadata.
+func (v Version) Render(opts ...NextOption) string {
+	cfg := &nextConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	prefix := cfg.prefix
+	if cfg.stripPrefix {
+		prefix = ""
+	}
+
+	s := fmt.Sprintf("%s%d.%d.%d", prefix, v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s = fmt.Sprintf("%s-%s", s, v.Prerelease)
+	}
+	if cfg.build != "" {
+		s = fmt.Sprintf("%s+%s", s, cfg.build)
+	}
+
+	return s
+}
+
+// VersionFormat knows how to parse, compare and render versions for a
+// particular packaging ecosystem. Changelog.Format names a registered
+// VersionFormat, so the same Changelog value can be round-tripped between
+// distro version schemes, including versions the strict-semver rules of
+// ToVersion reject. Version always holds major.minor.patch, so a two-part
+// version such as "2:1.0-1ubuntu3" is rendered back as "2:1.0.0-1ubuntu3".
+type VersionFormat interface {
+	Parse(s string) (Version, error)
+	Compare(a, b Version) int
+	String(v Version) string
+}
+
+var versionFormats = map[string]VersionFormat{}
+
+// RegisterVersionFormat registers f under name so that a Changelog can opt
+// into it via its Format field. Registering under a name that is already
+// taken replaces the existing VersionFormat.
+func RegisterVersionFormat(name string, f VersionFormat) {
+	versionFormats[name] = f
+}
+
+// VersionFormatFor returns the VersionFormat registered under name, and
+// whether one was found.
+func VersionFormatFor(name string) (VersionFormat, bool) {
+	f, ok := versionFormats[name]
+	return f, ok
+}
+
+func init() {
+	RegisterVersionFormat("semver", semverFormat{})
+	RegisterVersionFormat("dpkg", dpkgFormat{})
+	RegisterVersionFormat("rpm", rpmFormat{})
+	RegisterVersionFormat("pacman", pacmanFormat{})
+}
+
+// semverFormat is the strict-semver VersionFormat ToVersion already implements.
+type semverFormat struct{}
+
+func (semverFormat) Parse(s string) (Version, error) { return ToVersion(s) }
+func (semverFormat) Compare(a, b Version) int         { return a.Compare(b) }
+func (semverFormat) String(v Version) string          { return v.Render() }
+
+// dpkgVersionRe matches the "major.minor[.patch]" prefix of a dpkg upstream
+// version; anything left over (e.g. a "~rcN" tilde suffix or a "-revision")
+// is kept in Prerelease, less a leading "-".
+var dpkgVersionRe = regexp.MustCompile(`^(\d+)\.(\d+)(?:\.(\d+))?(.*)$`)
+
+// ParseDpkgVersion parses a Debian package version of the form
+// "[epoch:]upstream-version[-debian-revision]", honoring epochs and tildes.
+func ParseDpkgVersion(s string) (Version, error) {
+	var v Version
+	rest := s
+
+	if i := strings.Index(rest, ":"); i >= 0 {
+		epoch, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid epoch in %q: %w", s, err)
+		}
+		v.Epoch = epoch
+		rest = rest[i+1:]
+	}
+
+	m := dpkgVersionRe.FindStringSubmatch(rest)
+	if m == nil {
+		return Version{}, fmt.Errorf("%q: %w", s, ErrNotSemver)
+	}
+
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	v.Prerelease = strings.TrimPrefix(m[4], "-")
+
+	return v, nil
+}
+
+// formatEpochVersion renders v as "[epoch:]major.minor.patch[-prerelease]",
+// the shape shared by dpkg and RPM version strings.
+func formatEpochVersion(v Version) string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		s = fmt.Sprintf("%s-%s", s, v.Prerelease)
+	}
+	if v.Epoch != 0 {
+		s = fmt.Sprintf("%d:%s", v.Epoch, s)
+	}
+	return s
+}
+
+// dpkgFormat implements VersionFormat for Debian package versions, allowing
+// epochs, tildes and non-numeric upstream+debian_revision segments that the
+// strict-semver ToVersion rejects.
+type dpkgFormat struct{}
+
+func (dpkgFormat) Parse(s string) (Version, error) { return ParseDpkgVersion(s) }
+func (dpkgFormat) Compare(a, b Version) int         { return a.Compare(b) }
+func (dpkgFormat) String(v Version) string          { return formatEpochVersion(v) }
+
+// rpmFormat implements VersionFormat for RPM "epoch:version-release" strings,
+// comparing with the RPM segment comparator (rpmCompareSegment) rather than
+// dpkg's tilde-aware numeric/identifier comparison.
+type rpmFormat struct{}
+
+func (rpmFormat) Parse(s string) (Version, error) { return ParseDpkgVersion(s) }
+
+func (rpmFormat) Compare(a, b Version) int { return compareRpmStyle(a, b) }
+
+func (rpmFormat) String(v Version) string { return formatEpochVersion(v) }
+
+// pacmanFormat implements VersionFormat for Arch's "epoch:pkgver-pkgrel"
+// strings, compared with vercmp semantics (the same alternating
+// digit/alpha segment comparison pacman's vercmp inherited from RPM).
+type pacmanFormat struct{}
+
+func (pacmanFormat) Parse(s string) (Version, error) { return ParseDpkgVersion(s) }
+
+func (pacmanFormat) Compare(a, b Version) int { return compareRpmStyle(a, b) }
+
+func (pacmanFormat) String(v Version) string { return formatEpochVersion(v) }
+
+// compareRpmStyle compares a and b the way RPM's and pacman's vercmp
+// implementations do: epoch first, then the numeric version segment, then
+// the pre-release/release segment, each via rpmCompareSegment.
+func compareRpmStyle(a, b Version) int {
+	if c := compareInt(a.Epoch, b.Epoch); c != 0 {
+		return c
+	}
+	if c := rpmCompareSegment(fmt.Sprintf("%d.%d.%d", a.Major, a.Minor, a.Patch), fmt.Sprintf("%d.%d.%d", b.Major, b.Minor, b.Patch)); c != 0 {
+		return c
+	}
+	return rpmCompareSegment(a.Prerelease, b.Prerelease)
+}
+
+// rpmCompareSegment compares two version strings the way rpmvercmp does:
+// each string is walked as alternating runs of digits and letters, skipping
+// other separator bytes, with "~" sorting below everything else including
+// the end of the string. Digit runs compare numerically (after stripping
+// leading zeros); letter runs compare byte-wise; a side that runs out of
+// digits loses to one that still has them, and a side that runs out of
+// letters wins over one that still has them.
+func rpmCompareSegment(a, b string) int {
+	for len(a) > 0 || len(b) > 0 {
+		// Tildes are checked on both sides together, before either is
+		// stripped: checking them independently (as the two loops below
+		// do for ordinary separators) would make a tilde shared by both
+		// strings look asymmetric as soon as one side had already
+		// consumed its copy.
+		for (len(a) > 0 && a[0] == '~') || (len(b) > 0 && b[0] == '~') {
+			aTilde := len(a) > 0 && a[0] == '~'
+			bTilde := len(b) > 0 && b[0] == '~'
+			if aTilde != bTilde {
+				if aTilde {
+					return -1
+				}
+				return 1
+			}
+			a, b = a[1:], b[1:]
+		}
+		for len(a) > 0 && !isAlnum(a[0]) {
+			a = a[1:]
+		}
+		for len(b) > 0 && !isAlnum(b[0]) {
+			b = b[1:]
+		}
+		if len(a) == 0 || len(b) == 0 {
+			break
+		}
+
+		var sa, sb string
+		if isDigit(a[0]) {
+			sa, a = takeWhile(a, isDigit)
+			sb, b = takeWhile(b, isDigit)
+			if sb == "" {
+				return 1
+			}
+			sa = strings.TrimLeft(sa, "0")
+			sb = strings.TrimLeft(sb, "0")
+			if len(sa) != len(sb) {
+				if len(sa) > len(sb) {
+					return 1
+				}
+				return -1
+			}
+		} else {
+			sa, a = takeWhile(a, isAlpha)
+			sb, b = takeWhile(b, isAlpha)
+			if sb == "" {
+				return -1
+			}
+		}
+		if c := strings.Compare(sa, sb); c != 0 {
+			if c < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func isDigit(b byte) bool { return b >= '0' && b <= '9' }
+func isAlpha(b byte) bool { return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' }
+func isAlnum(b byte) bool { return isDigit(b) || isAlpha(b) }
+
+func takeWhile(s string, pred func(byte) bool) (string, string) {
+	i := 0
+	for i < len(s) && pred(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
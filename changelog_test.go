@@ -20,6 +20,7 @@ import (
 	"github.com/nekr0z/changelog"
 	"os"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -36,9 +37,9 @@ func TestParseMd(t *testing.T) {
 		t.Errorf("Parse returned error: %s", err)
 	}
 
-	ver := changelog.Version{2, 2, 0, ""}
+	ver := changelog.Version{0, 2, 2, 0, ""}
 
-	if _, ok := cl[ver]; !ok {
+	if _, ok := cl.Releases[ver]; !ok {
 		t.Errorf("release 2.2.0 not found")
 	}
 
@@ -47,30 +48,149 @@ func TestParseMd(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if cl[ver].Date != d {
-		t.Errorf("date mismatch: want %v, got %v", d, cl[ver].Date)
+	if cl.Releases[ver].Date != d {
+		t.Errorf("date mismatch: want %v, got %v", d, cl.Releases[ver].Date)
 	}
 
-	if len(cl[ver].Changes) != 1 {
+	if len(cl.Releases[ver].Changes) != 1 {
 		t.Fatalf("number of changes mismatch")
 	}
 
-	if cl[ver].Changes[0].Type != "Added" || cl[ver].Changes[0].Body != "a way to set custom battery threshold" {
-		t.Errorf("got %v", cl[ver].Changes[0])
+	if cl.Releases[ver].Changes[0].Type != "Added" || cl.Releases[ver].Changes[0].Body != "a way to set custom battery threshold" {
+		t.Errorf("got %v", cl.Releases[ver].Changes[0])
+	}
+}
+
+func TestParseMdUnreleased(t *testing.T) {
+	md := `# Changelog
+
+## [Unreleased]
+
+### Added
+
+- a way to set custom battery threshold
+
+## [1.3.0] - 2019-07-13
+
+### Fixed
+
+- some format discrepancies
+`
+
+	cl, err := changelog.ParseMd(strings.NewReader(md))
+	if err != nil {
+		t.Errorf("Parse returned error: %s", err)
+	}
+
+	if cl.Unreleased == nil {
+		t.Fatalf("unreleased section not found")
+	}
+
+	if len(cl.Unreleased.Changes) != 1 || cl.Unreleased.Changes[0].Type != "Added" || cl.Unreleased.Changes[0].Body != "a way to set custom battery threshold" {
+		t.Errorf("got %v", cl.Unreleased.Changes)
+	}
+
+	if _, ok := cl.Releases[changelog.Version{0, 1, 3, 0, ""}]; !ok {
+		t.Errorf("release 1.3.0 not found")
+	}
+}
+
+func TestParseMdUnreleasedDoesNotCollideWithZeroRelease(t *testing.T) {
+	md := `# Changelog
+
+## [Unreleased]
+
+### Added
+
+- a way to set custom battery threshold
+
+## [0.0.0] - 2020-01-01
+
+### Fixed
+
+- some format discrepancies
+`
+
+	cl, err := changelog.ParseMd(strings.NewReader(md))
+	if err != nil {
+		t.Errorf("Parse returned error: %s", err)
+	}
+
+	if cl.Unreleased == nil {
+		t.Fatalf("unreleased section not found")
+	}
+
+	if len(cl.Unreleased.Changes) != 1 || cl.Unreleased.Changes[0].Body != "a way to set custom battery threshold" {
+		t.Errorf("got %v", cl.Unreleased.Changes)
+	}
+
+	zero, ok := cl.Releases[changelog.Version{0, 0, 0, 0, ""}]
+	if !ok {
+		t.Fatalf("release 0.0.0 not found")
+	}
+
+	if len(zero.Changes) != 1 || zero.Changes[0].Body != "some format discrepancies" {
+		t.Errorf("got %v", zero.Changes)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	var (
+		cl = changelog.Changelog{
+			Unreleased: &changelog.Release{
+				Changes: []changelog.Change{
+					{"Added", "a way to set custom battery threshold"},
+				},
+			},
+			Releases: map[changelog.Version]changelog.Release{
+				changelog.Version{0, 1, 3, 0, ""}: changelog.Release{
+					Date: time.Date(2019, 7, 13, 0, 0, 0, 0, time.UTC),
+					Changes: []changelog.Change{
+						{"Fixed", "some format discrepancies"},
+						{"Added", "a useful feature"},
+					},
+				},
+			},
+		}
+		want = []byte(`## [Unreleased]
+
+### Added
+
+- a way to set custom battery threshold
+
+## [1.3.0] - 2019-07-13
+
+### Added
+
+- a useful feature
+
+### Fixed
+
+- some format discrepancies
+`)
+	)
+
+	result, err := cl.Markdown()
+	if err != nil {
+		t.Fatalf("Markdown changelog creation failed: %s", err)
+	}
+
+	if !bytes.Equal(result, want) {
+		t.Errorf("want:\n%s\ngot:\n%s", want, result)
 	}
 }
 
 func TestDebian(t *testing.T) {
 	var (
-		cl changelog.Changelog = map[changelog.Version]changelog.Release{
-			changelog.Version{1, 3, 0, ""}: changelog.Release{
+		cl = changelog.Changelog{Releases: map[changelog.Version]changelog.Release{
+			changelog.Version{0, 1, 3, 0, ""}: changelog.Release{
 				Date: time.Date(2019, 7, 13, 0, 0, 0, 0, time.UTC),
 				Changes: []changelog.Change{
 					{"Fixed", "some format discrepancies"},
 					{"Added", "a useful feature"},
 				},
 			},
-			changelog.Version{1, 3, 1, ""}: changelog.Release{
+			changelog.Version{0, 1, 3, 1, ""}: changelog.Release{
 				Date: time.Date(2019, 7, 18, 0, 0, 0, 0, time.UTC),
 				Changes: []changelog.Change{
 					{"Fixed", "another bug"},
@@ -78,7 +198,7 @@ func TestDebian(t *testing.T) {
 					{"Added", "more features"},
 				},
 			},
-			changelog.Version{1, 3, 1, "rc"}: changelog.Release{
+			changelog.Version{0, 1, 3, 1, "~rc"}: changelog.Release{
 				Date: time.Date(2019, 7, 17, 0, 0, 0, 0, time.UTC),
 				Changes: []changelog.Change{
 					{"Fixed", "another bug"},
@@ -86,7 +206,7 @@ func TestDebian(t *testing.T) {
 					{"Added", "more features"},
 				},
 			},
-		}
+		}}
 		want = []byte(`awesomeapp (1.3.1) stable; urgency=medium
 
   * Added: more features
@@ -112,10 +232,10 @@ awesomeapp (1.3.0) stable; urgency=medium
 `)
 	)
 
-	for k, rel := range cl {
+	for k, rel := range cl.Releases {
 		rel.Maintainer.Name = "John Doe"
 		rel.Maintainer.Email = "john@doe.me"
-		cl[k] = rel
+		cl.Releases[k] = rel
 	}
 
 	result, err := cl.Debian("awesomeapp")
@@ -127,10 +247,10 @@ awesomeapp (1.3.0) stable; urgency=medium
 		t.Errorf("want:\n%s\ngot:\n%s", want, result)
 	}
 
-	for k, rel := range cl {
+	for k, rel := range cl.Releases {
 		rel.Urgency = "medium"
 		rel.Distribution = "stable"
-		cl[k] = rel
+		cl.Releases[k] = rel
 	}
 
 	got, err := changelog.ParseDebian(bytes.NewReader(want))
@@ -143,17 +263,74 @@ awesomeapp (1.3.0) stable; urgency=medium
 	}
 }
 
+func TestRPM(t *testing.T) {
+	var (
+		cl = changelog.Changelog{Releases: map[changelog.Version]changelog.Release{
+			changelog.Version{0, 1, 3, 0, ""}: changelog.Release{
+				Date: time.Date(2019, 7, 12, 0, 0, 0, 0, time.UTC),
+				Changes: []changelog.Change{
+					{"Fixed", "some format discrepancies"},
+					{"Added", "a useful feature"},
+				},
+				RPMRelease: "1",
+			},
+			changelog.Version{0, 1, 3, 1, ""}: changelog.Release{
+				Date: time.Date(2019, 7, 19, 0, 0, 0, 0, time.UTC),
+				Changes: []changelog.Change{
+					{"Fixed", "another bug"},
+					{"Fixed", "all the bugs"},
+					{"Added", "more features"},
+				},
+				RPMRelease: "2",
+			},
+		}}
+		want = []byte(`* Fri Jul 19 2019 John Doe <john@doe.me> - 1.3.1-2
+- Added: more features
+- Fixed: another bug
+- Fixed: all the bugs
+
+* Fri Jul 12 2019 John Doe <john@doe.me> - 1.3.0-1
+- Added: a useful feature
+- Fixed: some format discrepancies
+`)
+	)
+
+	for k, rel := range cl.Releases {
+		rel.Maintainer.Name = "John Doe"
+		rel.Maintainer.Email = "john@doe.me"
+		cl.Releases[k] = rel
+	}
+
+	result, err := cl.RPM("awesomeapp")
+	if err != nil {
+		t.Fatalf("RPM changelog creation failed: %s", err)
+	}
+
+	if !bytes.Equal(result, want) {
+		t.Errorf("want:\n%s\ngot:\n%s", want, result)
+	}
+
+	got, err := changelog.ParseRPM(bytes.NewReader(want))
+	if err != nil {
+		t.Errorf("Error parsing RPM changelog: %s", err)
+	}
+
+	if !equal(t, got, cl) {
+		t.Errorf(" got: %v\nwant: %v", got, want)
+	}
+}
+
 func equal(t *testing.T, got, want changelog.Changelog) bool {
 	t.Helper()
-	if len(got) != len(want) {
+	if len(got.Releases) != len(want.Releases) {
 		return false
 	}
-	for v, rel1 := range got {
-		rel2, ok := want[v]
+	for v, rel1 := range got.Releases {
+		rel2, ok := want.Releases[v]
 		if !ok {
 			return false
 		}
-		if !rel1.Date.Equal(rel2.Date) || rel1.Urgency != rel2.Urgency || rel1.Distribution != rel2.Distribution || rel1.Maintainer.Name != rel2.Maintainer.Name || rel1.Maintainer.Email != rel2.Maintainer.Email {
+		if !rel1.Date.Equal(rel2.Date) || rel1.Urgency != rel2.Urgency || rel1.Distribution != rel2.Distribution || rel1.Maintainer.Name != rel2.Maintainer.Name || rel1.Maintainer.Email != rel2.Maintainer.Email || rel1.RPMRelease != rel2.RPMRelease {
 			return false
 		}
 		if len(rel1.Changes) != len(rel2.Changes) {
@@ -175,18 +352,47 @@ func equal(t *testing.T, got, want changelog.Changelog) bool {
 	return true
 }
 
+func TestRPMEpoch(t *testing.T) {
+	const in = `* Fri Jul 12 2019 John Doe <john@doe.me> - 2:1.3.0-1
+- Added: a useful feature
+`
+
+	cl, err := changelog.ParseRPM(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseRPM returned error: %s", err)
+	}
+
+	v := changelog.Version{2, 1, 3, 0, ""}
+	rel, ok := cl.Releases[v]
+	if !ok {
+		t.Fatalf("release %v not found", v)
+	}
+	rel.Maintainer.Name = "John Doe"
+	rel.Maintainer.Email = "john@doe.me"
+	cl.Releases[v] = rel
+
+	result, err := cl.RPM("awesomeapp")
+	if err != nil {
+		t.Fatalf("RPM changelog creation failed: %s", err)
+	}
+
+	if !bytes.Equal(result, []byte(in)) {
+		t.Errorf("want:\n%s\ngot:\n%s", in, result)
+	}
+}
+
 func TestToVersion(t *testing.T) {
 	testCases := []struct {
 		s string
 		v changelog.Version
 		e error
 	}{
-		{"1.1.0", changelog.Version{1, 1, 0, ""}, nil},
-		{"51.16.234+14a", changelog.Version{51, 16, 234, ""}, nil},
-		{"4.2.15-pre2.11", changelog.Version{4, 2, 15, "pre2.11"}, nil},
-		{"1.1.0.2", changelog.Version{0, 0, 0, ""}, changelog.ErrNotSemver},
-		{"1.3.-2-15", changelog.Version{0, 0, 0, ""}, changelog.ErrNotSemver},
-		{"v3.2.18-rc1+df8891", changelog.Version{0, 0, 0, ""}, changelog.ErrNotSemver},
+		{"1.1.0", changelog.Version{0, 1, 1, 0, ""}, nil},
+		{"51.16.234+14a", changelog.Version{0, 51, 16, 234, ""}, nil},
+		{"4.2.15-pre2.11", changelog.Version{0, 4, 2, 15, "pre2.11"}, nil},
+		{"1.1.0.2", changelog.Version{0, 0, 0, 0, ""}, changelog.ErrNotSemver},
+		{"1.3.-2-15", changelog.Version{0, 0, 0, 0, ""}, changelog.ErrNotSemver},
+		{"v3.2.18-rc1+df8891", changelog.Version{0, 0, 0, 0, ""}, changelog.ErrNotSemver},
 	}
 
 	for _, testCase := range testCases {
@@ -198,3 +404,341 @@ func TestToVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		name string
+		a, b changelog.Version
+		want int
+	}{
+		{"equal", changelog.Version{0, 1, 3, 0, ""}, changelog.Version{0, 1, 3, 0, ""}, 0},
+		{"patch differs", changelog.Version{0, 1, 3, 0, ""}, changelog.Version{0, 1, 3, 1, ""}, -1},
+		{"higher epoch wins", changelog.Version{2, 1, 0, 0, ""}, changelog.Version{1, 99, 0, 0, ""}, 1},
+		{"prerelease sorts before release", changelog.Version{0, 1, 0, 0, "rc"}, changelog.Version{0, 1, 0, 0, ""}, -1},
+		{"tilde sorts before release", changelog.Version{0, 1, 0, 0, "~rc1"}, changelog.Version{0, 1, 0, 0, ""}, -1},
+		{"tilde sorts before plain prerelease", changelog.Version{0, 1, 0, 0, "~rc1"}, changelog.Version{0, 1, 0, 0, "rc1"}, -1},
+		{"longer identifier list wins", changelog.Version{0, 1, 0, 0, "alpha"}, changelog.Version{0, 1, 0, 0, "alpha.1"}, -1},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.a.Compare(tc.b); sign(got) != tc.want {
+			t.Errorf("%s: want %d, got %d", tc.name, tc.want, got)
+		}
+		if got := tc.b.Compare(tc.a); sign(got) != -tc.want {
+			t.Errorf("%s (reversed): want %d, got %d", tc.name, -tc.want, got)
+		}
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func TestSatisfiedBy(t *testing.T) {
+	v := changelog.Version{0, 1, 3, 0, ""}
+
+	testCases := []struct {
+		op    string
+		other changelog.Version
+		want  bool
+	}{
+		{"<<", changelog.Version{0, 1, 3, 1, ""}, true},
+		{"<<", changelog.Version{0, 1, 3, 0, ""}, false},
+		{"<=", changelog.Version{0, 1, 3, 0, ""}, true},
+		{"=", changelog.Version{0, 1, 3, 0, ""}, true},
+		{"=", changelog.Version{0, 1, 3, 1, ""}, false},
+		{">=", changelog.Version{0, 1, 3, 0, ""}, true},
+		{">>", changelog.Version{0, 1, 2, 9, ""}, true},
+		{">>", changelog.Version{0, 1, 3, 0, ""}, false},
+	}
+
+	for _, tc := range testCases {
+		if got := v.SatisfiedBy(tc.op, tc.other); got != tc.want {
+			t.Errorf("%v %s %v: want %v, got %v", v, tc.op, tc.other, tc.want, got)
+		}
+	}
+}
+
+func TestParseDebianEpochTilde(t *testing.T) {
+	const in = `awesomeapp (2:1.3.1~rc) stable; urgency=medium
+
+  * Added: more features
+
+ -- John Doe <john@doe.me>  Wed, 17 Jul 2019 00:00:00 +0000
+
+awesomeapp (1.3.1) stable; urgency=medium
+
+  * Added: more features
+
+ -- John Doe <john@doe.me>  Thu, 18 Jul 2019 00:00:00 +0000
+`
+
+	cl, err := changelog.ParseDebian(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseDebian returned error: %s", err)
+	}
+
+	rc := changelog.Version{2, 1, 3, 1, "~rc"}
+	if _, ok := cl.Releases[rc]; !ok {
+		t.Errorf("release %v not found", rc)
+	}
+
+	plain := changelog.Version{0, 1, 3, 1, ""}
+	if _, ok := cl.Releases[plain]; !ok {
+		t.Errorf("release %v not found", plain)
+	}
+
+	if len(cl.Releases) != 2 {
+		t.Errorf("want 2 releases, got %d", len(cl.Releases))
+	}
+}
+
+func TestParseDebianDuplicateRelease(t *testing.T) {
+	const in = `awesomeapp (1.3.1) stable; urgency=medium
+
+  * Added: more features
+
+ -- John Doe <john@doe.me>  Thu, 18 Jul 2019 00:00:00 +0000
+
+awesomeapp (1.3.1) stable; urgency=medium
+
+  * Added: more features
+
+ -- John Doe <john@doe.me>  Thu, 18 Jul 2019 00:00:00 +0000
+`
+
+	if _, err := changelog.ParseDebian(strings.NewReader(in)); err == nil {
+		t.Errorf("want error for duplicate release, got nil")
+	}
+}
+
+func TestNext(t *testing.T) {
+	current := changelog.Version{0, 1, 3, 0, ""}
+
+	testCases := []struct {
+		name    string
+		changes []changelog.Change
+		opts    []changelog.NextOption
+		want    changelog.Version
+	}{
+		{
+			name:    "fix only bumps patch",
+			changes: []changelog.Change{{"Fixed", "a bug"}},
+			want:    changelog.Version{0, 1, 3, 1, ""},
+		},
+		{
+			name:    "added bumps minor",
+			changes: []changelog.Change{{"Fixed", "a bug"}, {"Added", "a feature"}},
+			want:    changelog.Version{0, 1, 4, 0, ""},
+		},
+		{
+			name:    "removed bumps major",
+			changes: []changelog.Change{{"Added", "a feature"}, {"Removed", "an old feature"}},
+			want:    changelog.Version{0, 2, 0, 0, ""},
+		},
+		{
+			name:    "force bump overrides inference",
+			changes: []changelog.Change{{"Fixed", "a bug"}},
+			opts:    []changelog.NextOption{changelog.ForceBump(changelog.Major)},
+			want:    changelog.Version{0, 2, 0, 0, ""},
+		},
+		{
+			name:    "pre-release is attached to the result",
+			changes: []changelog.Change{{"Added", "a feature"}},
+			opts:    []changelog.NextOption{changelog.WithPreRelease("rc.1")},
+			want:    changelog.Version{0, 1, 4, 0, "rc.1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		cl := changelog.Changelog{Unreleased: &changelog.Release{Changes: tc.changes}}
+
+		got, err := cl.Next(current, tc.opts...)
+		if err != nil {
+			t.Fatalf("%s: Next returned error: %s", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestBump(t *testing.T) {
+	cl := changelog.Changelog{
+		Releases:   map[changelog.Version]changelog.Release{},
+		Unreleased: &changelog.Release{Changes: []changelog.Change{{"Fixed", "a bug"}}},
+	}
+
+	v := changelog.Version{0, 1, 3, 1, ""}
+	cl.Bump(v)
+
+	if cl.Unreleased != nil {
+		t.Errorf("Unreleased section was not cleared")
+	}
+
+	rel, ok := cl.Releases[v]
+	if !ok {
+		t.Fatalf("release %v not found", v)
+	}
+	if rel.Date.IsZero() {
+		t.Errorf("release date was not set")
+	}
+	if len(rel.Changes) != 1 || rel.Changes[0].Body != "a bug" {
+		t.Errorf("changes were not moved to the new release: %v", rel.Changes)
+	}
+}
+
+func TestRender(t *testing.T) {
+	v := changelog.Version{0, 1, 3, 0, "rc.1"}
+
+	testCases := []struct {
+		opts []changelog.NextOption
+		want string
+	}{
+		{nil, "1.3.0-rc.1"},
+		{[]changelog.NextOption{changelog.WithPrefix("v")}, "v1.3.0-rc.1"},
+		{[]changelog.NextOption{changelog.WithPrefix("v"), changelog.StripPrefix()}, "1.3.0-rc.1"},
+		{[]changelog.NextOption{changelog.WithBuild("df8891")}, "1.3.0-rc.1+df8891"},
+	}
+
+	for _, tc := range testCases {
+		if got := v.Render(tc.opts...); got != tc.want {
+			t.Errorf("want %s, got %s", tc.want, got)
+		}
+	}
+}
+
+func TestVersionFormat(t *testing.T) {
+	testCases := []struct {
+		name       string
+		format     string
+		s          string
+		wantString string
+	}{
+		{"semver", "semver", "1.3.0-rc.1", "1.3.0-rc.1"},
+		{"dpkg", "dpkg", "2:1.0-1ubuntu3", "2:1.0.0-1ubuntu3"},
+		{"rpm", "rpm", "1.0.0-1.el7", "1.0.0-1.el7"},
+		{"pacman", "pacman", "1.0.0-1", "1.0.0-1"},
+	}
+
+	for _, tc := range testCases {
+		format, ok := changelog.VersionFormatFor(tc.format)
+		if !ok {
+			t.Fatalf("%s: no such VersionFormat registered", tc.name)
+		}
+
+		v, err := format.Parse(tc.s)
+		if err != nil {
+			t.Fatalf("%s: Parse(%q): %v", tc.name, tc.s, err)
+		}
+
+		if got := format.String(v); got != tc.wantString {
+			t.Errorf("%s: String: want %s, got %s", tc.name, tc.wantString, got)
+		}
+
+		if c := format.Compare(v, v); c != 0 {
+			t.Errorf("%s: Compare(v, v): want 0, got %d", tc.name, c)
+		}
+	}
+}
+
+func TestRpmStyleCompareOrdering(t *testing.T) {
+	// a and b are compared via Prerelease, since Major/Minor/Patch are
+	// parsed into ints and so can never carry the leading zeros or
+	// digit/alpha mix these cases are meant to exercise.
+	testCases := []struct {
+		name   string
+		format string
+		a, b   string
+		want   int
+	}{
+		{"numeric run, leading zeros stripped before length comparison", "rpm", "0010", "9", 1},
+		{"numeric run, reversed", "rpm", "9", "0010", -1},
+		{"trailing letter segment beats end of string", "rpm", "5a", "5", 1},
+		{"end of string loses to trailing letter segment", "rpm", "5", "5a", -1},
+		{"tilde sorts below plain", "rpm", "~rc", "", -1},
+		{"plain sorts above tilde", "rpm", "", "~rc", 1},
+		{"shared tilde falls through to the following digits", "rpm", "~rc1", "~rc2", -1},
+		{"numeric run, leading zeros stripped before length comparison", "pacman", "0010", "9", 1},
+		{"shared tilde falls through to the following digits", "pacman", "~rc1", "~rc2", -1},
+	}
+
+	for _, tc := range testCases {
+		format, ok := changelog.VersionFormatFor(tc.format)
+		if !ok {
+			t.Fatalf("%s: no such VersionFormat registered", tc.format)
+		}
+
+		a := changelog.Version{Major: 1, Prerelease: tc.a}
+		b := changelog.Version{Major: 1, Prerelease: tc.b}
+
+		if got := format.Compare(a, b); got != tc.want {
+			t.Errorf("%s: Compare(%q, %q): want %d, got %d", tc.name, tc.a, tc.b, tc.want, got)
+		}
+	}
+}
+
+func TestRegisterVersionFormat(t *testing.T) {
+	changelog.RegisterVersionFormat("test-format", testFormat{})
+
+	format, ok := changelog.VersionFormatFor("test-format")
+	if !ok {
+		t.Fatal("VersionFormatFor: want ok, got not found")
+	}
+
+	if _, ok := format.(testFormat); !ok {
+		t.Errorf("VersionFormatFor: want testFormat, got %T", format)
+	}
+
+	if _, ok := changelog.VersionFormatFor("no-such-format"); ok {
+		t.Error("VersionFormatFor(\"no-such-format\"): want not found, got ok")
+	}
+}
+
+type testFormat struct{}
+
+func (testFormat) Parse(s string) (changelog.Version, error) { return changelog.ToVersion(s) }
+func (testFormat) Compare(a, b changelog.Version) int        { return a.Compare(b) }
+func (testFormat) String(v changelog.Version) string         { return v.Render() }
+
+// TestDebianFormat checks that Debian() actually orders releases by the
+// VersionFormat named in Changelog.Format, rather than always falling back
+// to Version.Compare - i.e. that Format is wired in, not just stored.
+func TestDebianFormat(t *testing.T) {
+	changelog.RegisterVersionFormat("reverse-test-format", reverseFormat{})
+
+	cl := changelog.Changelog{
+		Format: "reverse-test-format",
+		Releases: map[changelog.Version]changelog.Release{
+			{Major: 0, Minor: 1, Patch: 0}: {},
+			{Major: 0, Minor: 2, Patch: 0}: {},
+		},
+	}
+
+	out, err := cl.Debian("awesomeapp")
+	if err != nil {
+		t.Fatalf("Debian: %v", err)
+	}
+
+	first := strings.Index(string(out), "0.1.0")
+	second := strings.Index(string(out), "0.2.0")
+	if first == -1 || second == -1 || first > second {
+		t.Errorf("Debian: want 0.1.0 rendered before 0.2.0 under reverseFormat, got:\n%s", out)
+	}
+}
+
+// reverseFormat orders the opposite way from Version.Compare, so a test
+// using it can tell whether a sort actually consulted the VersionFormat or
+// silently fell back to Version.Compare.
+type reverseFormat struct{}
+
+func (reverseFormat) Parse(s string) (changelog.Version, error) { return changelog.ToVersion(s) }
+func (reverseFormat) Compare(a, b changelog.Version) int        { return b.Compare(a) }
+func (reverseFormat) String(v changelog.Version) string         { return v.Render() }
@@ -27,6 +27,7 @@ import (
 const usageText = `changelog is a tool for converting keep-a-changelog-style changelog to Debian changelog.
 Usage:
 	changelog [flags] <filename>
+	changelog next [flags] <filename>
 Flags:
 `
 
@@ -37,6 +38,11 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "next" {
+		next(os.Args[2:])
+		return
+	}
+
 	flag.Parse()
 	flag.Usage = func() {
 		fmt.Fprint(os.Stderr, usageText)
@@ -61,9 +67,9 @@ func main() {
 		fatalf("could not parse changelog: %v", err)
 	}
 
-	for v, rel := range cl {
+	for v, rel := range cl.Releases {
 		rel.Maintainer = changelog.Maintainer{Name: *name, Email: *email}
-		cl[v] = rel
+		cl.Releases[v] = rel
 	}
 
 	b, err := cl.Debian(*pack)
@@ -86,6 +92,62 @@ func main() {
 	}
 }
 
+// next computes the next version from the Unreleased section of the
+// changelog at the path given in args and prints it to stdout, so that it
+// can drive e.g. `git tag` in release scripts.
+func next(args []string) {
+	fs := flag.NewFlagSet("next", flag.ExitOnError)
+	pre := fs.String("pre", "", "pre-release identifier for the next version")
+	build := fs.String("build", "", "build metadata for the next version")
+	prefix := fs.String("prefix", "", "prefix for the printed version, e.g. \"v\"")
+	fs.Parse(args)
+
+	fn := fs.Arg(0)
+	if fn == "" {
+		fmt.Fprintln(os.Stderr, "usage: changelog next [flags] <filename>")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		fatalf("could not open file: %v", err)
+	}
+	defer f.Close()
+
+	cl, err := changelog.ParseMd(f)
+	if err != nil {
+		fatalf("could not parse changelog: %v", err)
+	}
+
+	var current changelog.Version
+	for v := range cl.Releases {
+		if v.Compare(current) > 0 {
+			current = v
+		}
+	}
+
+	var nextOpts []changelog.NextOption
+	if *pre != "" {
+		nextOpts = append(nextOpts, changelog.WithPreRelease(*pre))
+	}
+
+	nextVer, err := cl.Next(current, nextOpts...)
+	if err != nil {
+		fatalf("could not compute next version: %v", err)
+	}
+
+	var renderOpts []changelog.NextOption
+	if *build != "" {
+		renderOpts = append(renderOpts, changelog.WithBuild(*build))
+	}
+	if *prefix != "" {
+		renderOpts = append(renderOpts, changelog.WithPrefix(*prefix))
+	}
+
+	fmt.Println(nextVer.Render(renderOpts...))
+}
+
 func fatalf(format string, args ...interface{}) {
 	fmt.Fprintf(os.Stderr, format+"\n", args...)
 	os.Exit(1)